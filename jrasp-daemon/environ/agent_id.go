@@ -0,0 +1,54 @@
+package environ
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"jrasp-daemon/utils"
+	"jrasp-daemon/zlog"
+)
+
+const AGENT_ID_FILE = "agent_id.txt"
+
+// getAgentID 返回本机 agent 的 snowflake id，与 hostname.txt 一样持久化到
+// <installDir>/config/agent_id.txt，首次启动生成后固化，重启直接复用，
+// 使运维可以按 agentID 在日志中检索同一台机器历史上所有的启停记录
+func getAgentID(execDir string) int64 {
+	initDefaultGenerator(execDir)
+
+	agentIDFile := filepath.Join(execDir, "config", AGENT_ID_FILE)
+
+	if id, err := readAgentIDFromFile(agentIDFile); err == nil {
+		return id
+	}
+
+	id := NextID()
+	if err := writeAgentIDToFile(agentIDFile, id); err != nil {
+		zlog.Errorf("write agent id to file error:%v", err)
+	}
+	return id
+}
+
+func readAgentIDFromFile(agentIDFile string) (int64, error) {
+	existed, err := utils.PathExists(agentIDFile)
+	if !existed {
+		if err == nil {
+			err = os.ErrNotExist
+		}
+		return 0, err
+	}
+	b, err := os.ReadFile(agentIDFile)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+}
+
+func writeAgentIDToFile(agentIDFile string, id int64) error {
+	if err := os.MkdirAll(filepath.Dir(agentIDFile), HOST_NAME_PERM); err != nil {
+		return err
+	}
+	return os.WriteFile(agentIDFile, []byte(strconv.FormatInt(id, 10)), HOST_NAME_PERM)
+}
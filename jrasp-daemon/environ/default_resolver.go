@@ -0,0 +1,102 @@
+package environ
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// 默认探测目标不再只硬编码单一的国内公共 DNS，避免在海外/隔离网络环境下
+// 首选目标必然超时
+var (
+	defaultIPv4Targets = []string{
+		"114.114.114.114:53",
+		"8.8.8.8:53",
+		"1.1.1.1:53",
+	}
+	defaultIPv6Targets = []string{
+		"[2400:3200::1]:53",
+		"[2001:4860:4860::8888]:53",
+	}
+)
+
+func defaultIPv4Chain() *ChainResolver {
+	return &ChainResolver{
+		Resolvers: []IPResolver{
+			&UDPDialResolver{Targets: defaultIPv4Targets, Timeout: 2 * time.Second},
+			&RouteTableResolver{},
+			&InterfaceScanResolver{},
+		},
+	}
+}
+
+func defaultIPv6Chain() *ChainResolver {
+	return &ChainResolver{
+		Resolvers: []IPResolver{
+			&UDPDialResolver{Targets: defaultIPv6Targets, Timeout: 2 * time.Second},
+			&InterfaceScanResolver{IncludeIPv6: true},
+		},
+	}
+}
+
+// GetDefaultIp 保持原有签名，内部改为走 ChainResolver：先尝试 UDP 拨号，
+// 失败后依次降级到路由表解析、网卡扫描。Environ.Ip 及其消费方（如
+// GetDefaultIface 按字符串比较 ip）都假定这是一个 ipv4 地址，因此即便
+// 某个降级策略意外混入了 ipv6 候选，这里也只挑第一个 ipv4 地址返回
+func GetDefaultIp() (string, error) {
+	ips, err := defaultIPv4Chain().Resolve(context.Background())
+	if err != nil {
+		return "", err
+	}
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip.String(), nil
+		}
+	}
+	return "", errors.New("getDefaultIp: no ipv4 candidate found")
+}
+
+// GetDefaultIpv6 与 GetDefaultIp 类似，优先返回全局单播 ipv6 地址，
+// 找不到全局地址时退而使用链路本地地址
+func GetDefaultIpv6() (string, error) {
+	ips, err := defaultIPv6Chain().Resolve(context.Background())
+	if err != nil {
+		return "", err
+	}
+	linkLocal := ""
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			continue
+		}
+		if ip.IsGlobalUnicast() && !ip.IsLinkLocalUnicast() {
+			return ip.String(), nil
+		}
+		if linkLocal == "" && ip.IsLinkLocalUnicast() {
+			linkLocal = ip.String()
+		}
+	}
+	if linkLocal != "" {
+		return linkLocal, nil
+	}
+	return "", errors.New("getDefaultIpv6: no ipv6 candidate found")
+}
+
+// GetIpCandidates 返回 ipv4 探测链路找到的全部候选地址，便于上报给服务端
+// 做多网卡场景下的排障
+func GetIpCandidates() []string {
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, r := range defaultIPv4Chain().Resolvers {
+		ips, err := r.Resolve(context.Background())
+		if err != nil {
+			continue
+		}
+		for _, ip := range ips {
+			if s := ip.String(); !seen[s] {
+				seen[s] = true
+				candidates = append(candidates, s)
+			}
+		}
+	}
+	return candidates
+}
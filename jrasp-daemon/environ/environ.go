@@ -13,6 +13,7 @@ import (
 
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
 	"github.com/shirou/gopsutil/v3/mem"
 )
 
@@ -31,19 +32,37 @@ const (
 const GB = 1024 * 1024 * 1024
 
 type Environ struct {
-	InstallDir  string `json:"installDir"`  // 安装目录
-	HostName    string `json:"hostName"`    // 主机/容器名称
-	Ip          string `json:"ip"`          // ipAddress
-	OsType      string `json:"osType"`      // 操作系统类型
-	BinFileName string `json:"binFileName"` // 磁盘可执行文件名
-	BinFileHash string `json:"binFileHash"` // 磁盘可执行文件的md5值
+	InstallDir   string   `json:"installDir"`   // 安装目录
+	HostName     string   `json:"hostName"`     // 主机/容器名称
+	AgentID      int64    `json:"agentID"`      // agent 唯一标识，snowflake id，持久化于 config/agent_id.txt
+	Ip           string   `json:"ip"`           // ipAddress
+	Ipv6         string   `json:"ipv6"`         // ipv6 地址，探测不到时为空
+	IpCandidates []string `json:"ipCandidates"` // ipv4 探测链路找到的全部候选地址，用于排障
+	OsType       string   `json:"osType"`       // 操作系统类型
+	BinFileName  string   `json:"binFileName"`  // 磁盘可执行文件名
+	BinFileHash  string   `json:"binFileHash"`  // 磁盘可执行文件的md5值
 
 	// 系统信息
 	TotalMem  uint64 `json:"totalMem"`  // 总内存 GB
+	FreeMem   uint64 `json:"freeMem"`   // 可用内存 GB
 	CpuCounts int    `json:"cpuCounts"` // logic cpu cores
 	FreeDisk  uint64 `json:"freeDisk"`  // 可用磁盘空间 GB
 	Version   string `json:"version"`   // rasp 版本
 
+	// 内核/发行版信息
+	KernelVersion  string `json:"kernelVersion"`  // 内核版本，如 5.15.0-1022-aws
+	OsDistribution string `json:"osDistribution"` // 发行版名称，如 Ubuntu 22.04.1 LTS
+	Arch           string `json:"arch"`           // CPU 架构，即 runtime.GOARCH
+	GoVersion      string `json:"goVersion"`      // 编译使用的 go 版本
+	BootTime       uint64 `json:"bootTime"`       // 系统启动时间，unix 秒
+
+	// CPU 信息，取第一颗物理 CPU 的型号即可代表整机
+	CpuModelName string  `json:"cpuModelName"` // CPU 型号
+	CpuMhz       float64 `json:"cpuMhz"`       // CPU 主频
+
+	// 内网/外网 ip
+	OutsideIp string `json:"outsideIp"` // 外网 ip，通过 getOutsideIp 探测，可能为空
+
 	// 编译信息
 	BuildDateTime  string `json:"buildDateTime"`
 	BuildGitBranch string `json:"buildGitBranch"`
@@ -57,7 +76,10 @@ type Environ struct {
 	PidFile string `json:"pidFile"`
 
 	// 是否为容器环境
+	// Deprecated: 使用 Runtime.Kind != RuntimeHost 判断，该字段仅保留兼容旧版上报协议
 	IsContainer bool `json:"isContainer"`
+	// 容器/k8s 运行时信息，取代粒度过粗的 IsContainer
+	Runtime *RuntimeInfo `json:"runtime"`
 	// 是否已连接server
 	IsConnectServer bool `json:"isConnectServer"`
 }
@@ -84,6 +106,9 @@ func NewEnviron() (*Environ, error) {
 	if err != nil {
 		return nil, err
 	}
+	// ipv6，探测失败不影响启动
+	ipv6Address, _ := GetDefaultIpv6()
+	ipCandidates := GetIpCandidates()
 
 	// mem
 	memInfo, _ := mem.VirtualMemory()
@@ -94,31 +119,93 @@ func NewEnviron() (*Environ, error) {
 	// cpu cnt
 	cpuCounts, err := cpu.Counts(true)
 
+	// cpu 型号/主频，取第一颗即可
+	cpuModelName, cpuMhz := getCpuModel()
+
+	// 内核版本
+	kernelVersion, _ := getKernelVersion()
+
+	// 发行版信息
+	osDistribution, _ := getOsDistribution()
+
+	// 系统启动时间
+	bootTime, _ := host.BootTime()
+
 	// .dockerenv
 	isContainer, err := utils.PathExists("/.dockerenv")
 
+	// 容器/k8s 运行时信息
+	runtimeInfo := DetectRuntime()
+
+	// 外网 ip，探测失败不影响启动
+	outsideIp, _ := getOutsideIp(defaultOutsideIpTimeout)
+
 	env := &Environ{
 		HostName:        getHostname(execDir),
+		AgentID:         getAgentID(execDir),
 		Ip:              ipAddress,
+		Ipv6:            ipv6Address,
+		IpCandidates:    ipCandidates,
 		InstallDir:      execDir,
 		OsType:          runtime.GOOS,
 		BinFileHash:     md5Str,
 		BinFileName:     execName,
 		TotalMem:        memInfo.Total / GB,
+		FreeMem:         memInfo.Free / GB,
 		CpuCounts:       cpuCounts,
 		FreeDisk:        FreeDisk,
 		Version:         defs.JRASP_DAEMON_VERSION,
+		KernelVersion:   kernelVersion,
+		OsDistribution:  osDistribution,
+		Arch:            runtime.GOARCH,
+		GoVersion:       runtime.Version(),
+		BootTime:        bootTime,
+		CpuModelName:    cpuModelName,
+		CpuMhz:          cpuMhz,
+		OutsideIp:       outsideIp,
 		BuildGitBranch:  BuildGitBranch,
 		BuildDateTime:   BuildDateTime,
 		BuildDecryptKey: BuildDecryptKey,
 		BuildGitCommit:  BuildGitCommit,
 		PidFile:         filepath.Join(execDir, defs.DAEMON_PID_FILE),
 		IsContainer:     isContainer,
+		Runtime:         runtimeInfo,
 		IsConnectServer: false,
 	}
 	return env, nil
 }
 
+// RefreshDynamic 重新计算易变字段（可用磁盘、可用内存、外网 ip），
+// 供守护进程定时调用，避免每次都重建整个 Environ
+func (e *Environ) RefreshDynamic() error {
+	freeDisk, err := GetInstallDisk(e.InstallDir)
+	if err != nil {
+		return err
+	}
+	e.FreeDisk = freeDisk
+
+	memInfo, err := mem.VirtualMemory()
+	if err != nil {
+		return err
+	}
+	e.FreeMem = memInfo.Free / GB
+
+	if outsideIp, err := getOutsideIp(defaultOutsideIpTimeout); err == nil {
+		e.OutsideIp = outsideIp
+	}
+
+	return nil
+}
+
+// getCpuModel 返回第一颗逻辑 CPU 的型号与主频，用于代表整机
+func getCpuModel() (modelName string, mhz float64) {
+	infos, err := cpu.Info()
+	if err != nil || len(infos) == 0 {
+		return "", 0
+	}
+	return infos[0].ModelName, infos[0].Mhz
+}
+
 func getHostname(execDir string) string {
 	hostName := ""
 
@@ -165,54 +252,6 @@ func isContainer() bool {
 	return isContainer
 }
 
-func getExternalIP() (string, error) {
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		return "", err
-	}
-	for _, iface := range ifaces {
-		if iface.Flags&net.FlagUp == 0 {
-			continue // interface down
-		}
-		if iface.Flags&net.FlagLoopback != 0 {
-			continue // loopback interface
-		}
-		addrs, err := iface.Addrs()
-		if err != nil {
-			return "", err
-		}
-		for _, addr := range addrs {
-			var ip net.IP
-			switch v := addr.(type) {
-			case *net.IPNet:
-				ip = v.IP
-			case *net.IPAddr:
-				ip = v.IP
-			}
-			if ip == nil || ip.IsLoopback() {
-				continue
-			}
-			ip = ip.To4()
-			if ip == nil {
-				continue // not an ipv4 address
-			}
-			return ip.String(), nil
-		}
-	}
-	return "", errors.New("are you connected to the network?")
-}
-
-func GetDefaultIp() (string, error) {
-	conn, err := net.Dial("udp", "114.114.114.114:53")
-	if err != nil {
-		return "", err
-	}
-	defer conn.Close()
-	localAddr := conn.LocalAddr().(*net.UDPAddr)
-	ip := strings.Split(localAddr.IP.String(), ":")[0]
-	return ip, nil
-}
-
 func GetDefaultIface() (*net.Interface, error) {
 	defaultIP, err := GetDefaultIp()
 	if err != nil {
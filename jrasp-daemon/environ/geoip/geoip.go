@@ -0,0 +1,169 @@
+// Package geoip 提供离线 IP 归属地查询能力，使服务端无需额外网络依赖
+// 即可展示攻击来源的国家/省份/城市/运营商信息。
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Location 是一次 IP 归属地查询的结果
+type Location struct {
+	Country  string `json:"country"`
+	Region   string `json:"region"` // ip2region 特有的大区字段，qqwry 下为空
+	Province string `json:"province"`
+	City     string `json:"city"`
+	ISP      string `json:"isp"`
+}
+
+// format 标识离线库的二进制格式
+type format int
+
+const (
+	formatQQWry format = iota
+	formatIP2Region
+)
+
+func (f format) String() string {
+	switch f {
+	case formatQQWry:
+		return "qqwry"
+	case formatIP2Region:
+		return "ip2region"
+	default:
+		return "unknown"
+	}
+}
+
+// parser 由具体格式（qqwry/ip2region）实现，屏蔽底层索引结构差异
+type parser interface {
+	lookup(ip net.IP) (*Location, error)
+}
+
+const defaultCacheSize = 4096
+
+// DB 是一个可热加载的离线 IP 库句柄
+type DB struct {
+	mu       sync.RWMutex
+	path     string
+	dbFormat format
+	p        parser
+	cache    *lruCache
+}
+
+// Open 加载 path 指向的离线库文件，根据文件头自动识别 qqwry/ip2region 格式
+func Open(path string) (*DB, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: read db file error: %w", err)
+	}
+
+	f, p, err := parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: parse db file error: %w", err)
+	}
+
+	return &DB{
+		path:     path,
+		dbFormat: f,
+		p:        p,
+		cache:    newLRUCache(defaultCacheSize),
+	}, nil
+}
+
+// parse 根据文件头部内容识别格式并构造对应的 parser。
+// looksLikeIP2Region 会校验 header 开头的魔数，而不仅仅是文件大小——
+// 真实的 qqwry.dat 动辄几兆字节，单纯按体积判断会让它被误判为 ip2region 格式，
+// 因此 qqwry 只在魔数缺失时才作为兜底格式使用。
+func parse(b []byte) (format, parser, error) {
+	if looksLikeIP2Region(b) {
+		p, err := newIP2RegionParser(b)
+		if err != nil {
+			return 0, nil, err
+		}
+		return formatIP2Region, p, nil
+	}
+
+	p, err := newQQWryParser(b)
+	if err != nil {
+		return 0, nil, err
+	}
+	return formatQQWry, p, nil
+}
+
+// Path 返回当前生效的离线库文件路径
+func (d *DB) Path() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.path
+}
+
+// Format 返回当前识别出的离线库格式名称，便于日志/诊断
+func (d *DB) Format() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.dbFormat.String()
+}
+
+// Lookup 查询 ip 的归属地，结果会被缓存
+func (d *DB) Lookup(ip net.IP) (*Location, error) {
+	key := ip.String()
+
+	if v, ok := d.cache.get(key); ok {
+		return v, nil
+	}
+
+	d.mu.RLock()
+	p := d.p
+	path := d.path
+	dbFormat := d.dbFormat
+	d.mu.RUnlock()
+
+	loc, err := p.lookup(ip)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: lookup %s in %s (%s): %w", ip, path, dbFormat, err)
+	}
+
+	d.cache.add(key, loc)
+	return loc, nil
+}
+
+// Reload 重新读取 path 指向的文件并原子替换当前索引，用于离线库热更新
+func (d *DB) Reload(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("geoip: reload read db file error: %w", err)
+	}
+
+	f, p, err := parse(b)
+	if err != nil {
+		return fmt.Errorf("geoip: reload parse db file error: %w", err)
+	}
+
+	d.mu.Lock()
+	d.path = path
+	d.dbFormat = f
+	d.p = p
+	d.mu.Unlock()
+
+	d.cache.clear()
+	return nil
+}
+
+const (
+	geoipHomeEnv  = "JRASP_GEOIP_HOME"
+	geoipSubDir   = "data/geoip"
+	geoipFileName = "qqwry.dat"
+)
+
+// ResolveDBPath 解析离线库文件路径：优先读取 JRASP_GEOIP_HOME 环境变量指定的目录，
+// 否则回退到 <installDir>/data/geoip/ 下默认文件名
+func ResolveDBPath(installDir string) string {
+	if home := os.Getenv(geoipHomeEnv); home != "" {
+		return filepath.Join(home, geoipFileName)
+	}
+	return filepath.Join(installDir, geoipSubDir, geoipFileName)
+}
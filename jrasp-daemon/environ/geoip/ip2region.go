@@ -0,0 +1,134 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+)
+
+// 本文件实现的是 jrasp 自用的、受 ip2region xdb 启发的简化离线库格式，
+// 并非与上游 ip2region.xdb 二进制兼容（上游索引区是定长记录 + 独立 data 区，
+// 这里为了实现简单，segment 记录本身就是变长的，data 内联存储在索引里）。
+// 要加载这种格式，离线库文件需由本项目的导出工具生成，而不能直接使用从
+// ip2region 官方仓库下载的 xdb 文件。布局：
+//   - 256 字节定长 header：前 ip2regionMagic 字节是魔数+版本标记，用于和 qqwry.dat
+//     区分（qqwry 没有固定的文件头内容，仅靠文件大小无法可靠区分两种格式）
+//   - 512KB 矢量索引区：以 ip 的高 2 字节为 key，每项是 (firstPtr, lastPtr) 两个 4 字节偏移，
+//     指向该 /16 网段对应 segment 记录在数据区的起止位置
+//   - segment 区：变长记录 [4 字节 start_ip][4 字节 end_ip][2 字节 data_len][data_len 字节 data]，
+//     data 为 "country|region|province|city|isp" 管道分隔字符串
+const (
+	ip2regionMagic      = "JRASPGEOV1" // 自定义魔数+版本标记，长度 10 字节
+	ip2regionHeaderLen  = 256
+	ip2regionVectorLen  = 256 * 256 * 8 // 512KB 向量索引，256*256 个 /16 网段，每项 8 字节
+	ip2regionSegHeadLen = 4 + 4 + 2
+)
+
+type ip2regionParser struct {
+	data []byte
+}
+
+// looksLikeIP2Region 判断文件是否为本项目的简化 ip2region 格式：
+// 文件大小需与 header+向量索引区吻合，且 header 开头必须是 ip2regionMagic，
+// 仅凭大小无法和体积同样可观的 qqwry.dat 区分开
+func looksLikeIP2Region(b []byte) bool {
+	if len(b) < ip2regionHeaderLen+ip2regionVectorLen {
+		return false
+	}
+	return string(b[:len(ip2regionMagic)]) == ip2regionMagic
+}
+
+func newIP2RegionParser(data []byte) (*ip2regionParser, error) {
+	if len(data) < ip2regionHeaderLen+ip2regionVectorLen {
+		return nil, errors.New("ip2region: file too small")
+	}
+	return &ip2regionParser{data: data}, nil
+}
+
+func (r *ip2regionParser) lookup(ip net.IP) (*Location, error) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return nil, errors.New("ip2region: only ipv4 is supported")
+	}
+	target := binary.BigEndian.Uint32(ip4)
+
+	firstPtr, lastPtr := r.vectorRange(ip4[0], ip4[1])
+	if firstPtr == 0 && lastPtr == 0 {
+		return nil, errors.New("ip2region: ip not found")
+	}
+
+	data, err := r.searchSegments(target, firstPtr, lastPtr)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseIP2RegionData(data), nil
+}
+
+// vectorRange 读取 ip 高两字节对应的 (firstPtr, lastPtr)
+func (r *ip2regionParser) vectorRange(b0, b1 byte) (uint32, uint32) {
+	idx := (uint32(b0)*256 + uint32(b1)) * 8
+	base := ip2regionHeaderLen + idx
+	firstPtr := binary.LittleEndian.Uint32(r.data[base : base+4])
+	lastPtr := binary.LittleEndian.Uint32(r.data[base+4 : base+8])
+	return firstPtr, lastPtr
+}
+
+// searchSegments 在 [firstPtr, lastPtr] 范围内顺序扫描覆盖 target 的 segment 记录。
+// 记录是变长的（data 内联存储），下一条记录的偏移只有读完当前记录的 data_len
+// 才能确定，因而不能像定长索引那样做等步长二分查找，只能线性扫描。
+func (r *ip2regionParser) searchSegments(target, firstPtr, lastPtr uint32) ([]byte, error) {
+	offset := firstPtr
+	for offset <= lastPtr {
+		if int(offset)+ip2regionSegHeadLen > len(r.data) {
+			return nil, errors.New("ip2region: segment offset out of range")
+		}
+
+		startIP := binary.LittleEndian.Uint32(r.data[offset : offset+4])
+		endIP := binary.LittleEndian.Uint32(r.data[offset+4 : offset+8])
+		dataLen := binary.LittleEndian.Uint16(r.data[offset+8 : offset+10])
+		dataOffset := offset + ip2regionSegHeadLen
+		if int(dataOffset+uint32(dataLen)) > len(r.data) {
+			return nil, errors.New("ip2region: data out of range")
+		}
+
+		if target >= startIP && target <= endIP {
+			return r.data[dataOffset : dataOffset+uint32(dataLen)], nil
+		}
+
+		offset = dataOffset + uint32(dataLen)
+	}
+
+	return nil, errors.New("ip2region: ip not found")
+}
+
+// parseIP2RegionData 拆分 "country|region|province|city|isp" 管道分隔字符串
+func parseIP2RegionData(data []byte) *Location {
+	parts := strings.Split(string(data), "|")
+	loc := &Location{}
+	if len(parts) > 0 {
+		loc.Country = emptyDash(parts[0])
+	}
+	if len(parts) > 1 {
+		loc.Region = emptyDash(parts[1])
+	}
+	if len(parts) > 2 {
+		loc.Province = emptyDash(parts[2])
+	}
+	if len(parts) > 3 {
+		loc.City = emptyDash(parts[3])
+	}
+	if len(parts) > 4 {
+		loc.ISP = emptyDash(parts[4])
+	}
+	return loc
+}
+
+// emptyDash ip2region 用 "0" 表示字段缺失
+func emptyDash(s string) string {
+	if s == "0" {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,176 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// qqwry.dat 格式说明：
+//   - 文件头 8 字节：[4 字节首条索引偏移][4 字节末条索引偏移]
+//   - 索引区：每条记录 7 字节，[4 字节起始 ip，小端][3 字节记录区偏移]，按起始 ip 升序排列
+//   - 记录区：每条记录是 "country\0area\0" 形式的 C 字符串，或以 0x01/0x02 开头的重定向：
+//     0x01 表示完整重定向到另一条记录；0x02 表示仅国家字段重定向，area 紧随其后内联存储
+const (
+	qqwryHeaderLen    = 8
+	qqwryIndexLen     = 7
+	qqwryRedirectFull = 0x01
+	qqwryRedirectArea = 0x02
+	qqwryMaxRedirects = 2
+)
+
+type qqwryParser struct {
+	data       []byte
+	firstIndex uint32
+	lastIndex  uint32
+	count      uint32
+}
+
+func newQQWryParser(data []byte) (*qqwryParser, error) {
+	if len(data) < qqwryHeaderLen {
+		return nil, errors.New("qqwry: file too small")
+	}
+
+	first := binary.LittleEndian.Uint32(data[0:4])
+	last := binary.LittleEndian.Uint32(data[4:8])
+	if last < first || int(last+qqwryIndexLen) > len(data) {
+		return nil, errors.New("qqwry: invalid header")
+	}
+
+	return &qqwryParser{
+		data:       data,
+		firstIndex: first,
+		lastIndex:  last,
+		count:      (last-first)/qqwryIndexLen + 1,
+	}, nil
+}
+
+func (q *qqwryParser) lookup(ip net.IP) (*Location, error) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return nil, errors.New("qqwry: only ipv4 is supported")
+	}
+	target := binary.BigEndian.Uint32(ip4)
+
+	offset, err := q.searchIndex(target)
+	if err != nil {
+		return nil, err
+	}
+
+	country, area, err := q.readRecord(offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Location{
+		Country: country,
+		ISP:     area,
+	}, nil
+}
+
+// searchIndex 在升序索引区二分查找 <= target 的最大起始 ip，返回其记录区偏移
+func (q *qqwryParser) searchIndex(target uint32) (uint32, error) {
+	lo, hi := uint32(0), q.count-1
+	var best uint32
+	found := false
+
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+		recOffset := q.firstIndex + mid*qqwryIndexLen
+		startIP := binary.LittleEndian.Uint32(q.data[recOffset : recOffset+4])
+
+		if startIP <= target {
+			best = recOffset
+			found = true
+			if mid == hi {
+				break
+			}
+			lo = mid + 1
+		} else {
+			if mid == 0 {
+				break
+			}
+			hi = mid - 1
+		}
+	}
+
+	if !found {
+		return 0, errors.New("qqwry: ip not found")
+	}
+
+	return uint24(q.data[best+4 : best+7]), nil
+}
+
+// readRecord 读取 recordOffset 处的记录，跟随最多 qqwryMaxRedirects 次重定向。
+// recordOffset 只有在第一跳（即直接来自索引）时，才以 4 字节 ip 段结束 ip 开头，
+// 需要跳过这 4 字节再去读重定向模式字节；0x01 完整重定向之后的目标偏移已经
+// 直接指向 country/area 数据本身，不再带这个前缀
+func (q *qqwryParser) readRecord(recordOffset uint32) (country string, area string, err error) {
+	for hop := 0; hop <= qqwryMaxRedirects; hop++ {
+		bodyOffset := recordOffset
+		if hop == 0 {
+			bodyOffset = recordOffset + 4
+		}
+		if int(bodyOffset) >= len(q.data) {
+			return "", "", errors.New("qqwry: record offset out of range")
+		}
+
+		b := q.data[bodyOffset:]
+		if len(b) == 0 {
+			return "", "", errors.New("qqwry: empty record")
+		}
+
+		switch b[0] {
+		case qqwryRedirectFull:
+			recordOffset = uint24(b[1:4])
+			continue
+		case qqwryRedirectArea:
+			country = readCString(q.data, uint24(b[1:4]))
+			area = readArea(q.data, bodyOffset+4)
+			return decodeGBK(country), decodeGBK(area), nil
+		default:
+			country = readCString(q.data, bodyOffset)
+			areaOffset := bodyOffset + uint32(len(country)) + 1
+			area = readArea(q.data, areaOffset)
+			return decodeGBK(country), decodeGBK(area), nil
+		}
+	}
+	return "", "", fmt.Errorf("qqwry: too many redirects (max %d)", qqwryMaxRedirects)
+}
+
+// readArea 读取 area 字段，area 字段自身也可能是一次完整重定向
+func readArea(data []byte, offset uint32) string {
+	if int(offset) >= len(data) {
+		return ""
+	}
+	if data[offset] == qqwryRedirectFull {
+		offset = uint24(data[offset+1 : offset+4])
+	}
+	return readCString(data, offset)
+}
+
+func readCString(data []byte, offset uint32) string {
+	if int(offset) >= len(data) {
+		return ""
+	}
+	end := offset
+	for int(end) < len(data) && data[end] != 0 {
+		end++
+	}
+	return string(data[offset:end])
+}
+
+func decodeGBK(s string) string {
+	out, err := simplifiedchinese.GBK.NewDecoder().String(s)
+	if err != nil {
+		return s
+	}
+	return out
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
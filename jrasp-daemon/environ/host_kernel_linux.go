@@ -0,0 +1,15 @@
+package environ
+
+import (
+	"os"
+	"strings"
+)
+
+// getKernelVersion 从 /proc/sys/kernel/osrelease 读取内核版本，如 5.15.0-1022-aws
+func getKernelVersion() (string, error) {
+	b, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+
+package environ
+
+// getKernelVersion 在 linux/windows 之外的平台（如 darwin）没有实现，
+// 返回空字符串而不是让包在这些 GOOS 下编译失败
+func getKernelVersion() (string, error) {
+	return "", nil
+}
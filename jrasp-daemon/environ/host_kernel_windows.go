@@ -0,0 +1,13 @@
+package environ
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// getKernelVersion 通过 RtlGetVersion 获取 windows 内核版本号
+func getKernelVersion() (string, error) {
+	info := windows.RtlGetVersion()
+	return fmt.Sprintf("%d.%d.%d", info.MajorVersion, info.MinorVersion, info.BuildNumber), nil
+}
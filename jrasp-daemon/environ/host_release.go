@@ -0,0 +1,39 @@
+package environ
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// getOsDistribution 解析 /etc/os-release，返回形如 "Ubuntu 22.04.1 LTS" 的发行版描述
+// 非 linux 平台或文件不存在时返回空字符串
+func getOsDistribution() (string, error) {
+	if runtime.GOOS != "linux" {
+		return "", nil
+	}
+
+	b, err := os.ReadFile("/etc/os-release")
+	if err != nil {
+		return "", err
+	}
+
+	var name, version string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "PRETTY_NAME="):
+			return unquote(strings.TrimPrefix(line, "PRETTY_NAME=")), nil
+		case strings.HasPrefix(line, "NAME="):
+			name = unquote(strings.TrimPrefix(line, "NAME="))
+		case strings.HasPrefix(line, "VERSION="):
+			version = unquote(strings.TrimPrefix(line, "VERSION="))
+		}
+	}
+
+	return strings.TrimSpace(name + " " + version), nil
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, `"`)
+}
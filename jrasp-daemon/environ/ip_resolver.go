@@ -0,0 +1,143 @@
+package environ
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// IPResolver 是一种获取本机出口 ip 的策略。多种实现可通过 ChainResolver 组合，
+// 在网络隔离、纯 IPv6、出站 DNS 被封禁等环境下逐级降级，不再硬编码单一探测方式
+type IPResolver interface {
+	Resolve(ctx context.Context) ([]net.IP, error)
+}
+
+// WarnLogger 在 ChainResolver 跳过首个策略、回退到后续策略时被调用，
+// 默认不输出，daemon 启动时可替换为真正的日志实现
+var WarnLogger = func(format string, args ...interface{}) {}
+
+// UDPDialResolver 依次对 Targets 发起 UDP 拨号，取本地出口地址。
+// UDP 拨号不会真正发包，只是触发内核路由表查找，因此不依赖对端可达
+type UDPDialResolver struct {
+	Targets []string // host:port 列表，按顺序尝试
+	Timeout time.Duration
+}
+
+func (r *UDPDialResolver) Resolve(ctx context.Context) ([]net.IP, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+
+	var lastErr error
+	for _, target := range r.Targets {
+		d := net.Dialer{Timeout: timeout}
+		conn, err := d.DialContext(ctx, "udp", target)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ip := conn.LocalAddr().(*net.UDPAddr).IP
+		conn.Close()
+		return []net.IP{ip}, nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("udpDialResolver: no targets configured")
+	}
+	return nil, lastErr
+}
+
+// InterfaceScanResolver 枚举所有非 loopback 网卡地址，按 RFC1918 私网优先、
+// 公网次之排序返回，取代过去只取第一张网卡第一个地址的 getExternalIP
+type InterfaceScanResolver struct {
+	IncludeIPv6 bool
+}
+
+func (r *InterfaceScanResolver) Resolve(ctx context.Context) ([]net.IP, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var private, public []net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ip := extractIP(addr)
+			if ip == nil || ip.IsLoopback() {
+				continue
+			}
+			if ip.To4() == nil && !r.IncludeIPv6 {
+				continue
+			}
+			if ip.IsPrivate() {
+				private = append(private, ip)
+			} else {
+				public = append(public, ip)
+			}
+		}
+	}
+
+	all := append(private, public...)
+	if len(all) == 0 {
+		return nil, errors.New("interfaceScanResolver: no candidate ip found")
+	}
+	return all, nil
+}
+
+func extractIP(addr net.Addr) net.IP {
+	switch v := addr.(type) {
+	case *net.IPNet:
+		return v.IP
+	case *net.IPAddr:
+		return v.IP
+	}
+	return nil
+}
+
+// StaticResolver 直接返回配置写死的 ip，作为所有探测策略都失败时的兜底
+type StaticResolver struct {
+	IPs []net.IP
+}
+
+func (r *StaticResolver) Resolve(ctx context.Context) ([]net.IP, error) {
+	if len(r.IPs) == 0 {
+		return nil, errors.New("staticResolver: no ip configured")
+	}
+	return r.IPs, nil
+}
+
+// ChainResolver 按顺序尝试一组 IPResolver，返回第一个成功的结果
+type ChainResolver struct {
+	Resolvers []IPResolver
+}
+
+func (c *ChainResolver) Resolve(ctx context.Context) ([]net.IP, error) {
+	var lastErr error
+	for i, r := range c.Resolvers {
+		ips, err := r.Resolve(ctx)
+		if err != nil || len(ips) == 0 {
+			if err == nil {
+				err = errors.New("chainResolver: resolver returned no ip")
+			}
+			lastErr = err
+			continue
+		}
+		if i > 0 {
+			WarnLogger("ip resolver fell back to strategy #%d (%T) after %d prior failures", i, r, i)
+		}
+		return ips, nil
+	}
+	if lastErr == nil {
+		lastErr = errors.New("chainResolver: no resolver configured")
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,74 @@
+package environ
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+)
+
+// RouteTableResolver 解析 /proc/net/route 找到默认路由所在网卡，不发起任何
+// 网络请求即可确定出口 ip，适合出站流量被完全阻断的环境。默认只返回 ipv4 地址——
+// Environ.Ip 及 GetDefaultIface 都按 ipv4 字符串比较，一旦这里混入 ipv6 地址
+// 会导致 Ip 字段在双栈主机上悄悄变成 ipv6
+type RouteTableResolver struct {
+	IncludeIPv6 bool
+}
+
+func (r *RouteTableResolver) Resolve(ctx context.Context) ([]net.IP, error) {
+	ifaceName, err := defaultRouteIface()
+	if err != nil {
+		return nil, err
+	}
+
+	iface, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	for _, addr := range addrs {
+		ip := extractIP(addr)
+		if ip == nil || ip.IsLoopback() {
+			continue
+		}
+		if ip.To4() == nil && !r.IncludeIPv6 {
+			continue
+		}
+		ips = append(ips, ip)
+	}
+	if len(ips) == 0 {
+		return nil, errors.New("routeTableResolver: default route interface has no usable ip")
+	}
+	return ips, nil
+}
+
+// defaultRouteIface 解析 /proc/net/route，找到 Destination 字段为 00000000（即默认路由）的行，
+// 返回其网卡名
+func defaultRouteIface() (string, error) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // 跳过表头
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		if fields[1] == "00000000" {
+			return fields[0], nil
+		}
+	}
+	return "", errors.New("defaultRouteIface: no default route found")
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !windows
+
+package environ
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// RouteTableResolver 在 linux/windows 之外的平台（如 darwin）没有实现，
+// 始终失败以触发 ChainResolver 降级到 InterfaceScanResolver，而不是让包
+// 在这些 GOOS 下编译失败
+type RouteTableResolver struct {
+	IncludeIPv6 bool
+}
+
+func (r *RouteTableResolver) Resolve(ctx context.Context) ([]net.IP, error) {
+	return nil, errors.New("routeTableResolver: not implemented on this platform")
+}
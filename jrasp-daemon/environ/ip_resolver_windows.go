@@ -0,0 +1,17 @@
+package environ
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// RouteTableResolver 在 windows 上暂不通过路由表直接定位默认网卡出口 ip，
+// 降级依赖 UDPDialResolver/InterfaceScanResolver 兜底
+type RouteTableResolver struct {
+	IncludeIPv6 bool
+}
+
+func (r *RouteTableResolver) Resolve(ctx context.Context) ([]net.IP, error) {
+	return nil, errors.New("routeTableResolver: not implemented on windows")
+}
@@ -0,0 +1,75 @@
+package environ
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutsideIpEndpoint 是探测外网 ip 所请求的地址，返回内容需为纯文本 ip，
+// 可通过运维配置替换为私有化部署的探测服务
+var OutsideIpEndpoint = "http://ifconfig.me/ip"
+
+const (
+	defaultOutsideIpTimeout = 3 * time.Second
+	outsideIpCacheTTL       = 5 * time.Minute
+
+	// outsideIpDisableEnv 设置为非空时跳过外网 ip 探测，供隔离/离线环境的运维关闭
+	// 该次出站请求，避免启动时阻塞
+	outsideIpDisableEnv = "JRASP_DISABLE_OUTSIDE_IP"
+)
+
+var outsideIpCache struct {
+	sync.Mutex
+	ip        string
+	fetchedAt time.Time
+}
+
+// getOutsideIp 请求 OutsideIpEndpoint 获取外网 ip，结果缓存 outsideIpCacheTTL，
+// 避免心跳周期内频繁对外发起请求。隔离/离线环境可通过 JRASP_DISABLE_OUTSIDE_IP
+// 环境变量关闭该探测，避免启动时产生不必要的出站请求
+func getOutsideIp(timeout time.Duration) (string, error) {
+	if os.Getenv(outsideIpDisableEnv) != "" {
+		return "", fmt.Errorf("getOutsideIp: disabled via %s", outsideIpDisableEnv)
+	}
+
+	outsideIpCache.Lock()
+	if outsideIpCache.ip != "" && time.Since(outsideIpCache.fetchedAt) < outsideIpCacheTTL {
+		ip := outsideIpCache.ip
+		outsideIpCache.Unlock()
+		return ip, nil
+	}
+	outsideIpCache.Unlock()
+
+	client := http.Client{Timeout: timeout}
+	resp, err := client.Get(OutsideIpEndpoint)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("getOutsideIp: unexpected status %d from %s", resp.StatusCode, OutsideIpEndpoint)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	ip := strings.TrimSpace(string(b))
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("getOutsideIp: response is not a valid ip: %q", ip)
+	}
+
+	outsideIpCache.Lock()
+	outsideIpCache.ip = ip
+	outsideIpCache.fetchedAt = time.Now()
+	outsideIpCache.Unlock()
+
+	return ip, nil
+}
@@ -0,0 +1,127 @@
+package environ
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"jrasp-daemon/utils"
+)
+
+// 容器运行时类型
+const (
+	RuntimeHost          = "host"
+	RuntimeDocker        = "docker"
+	RuntimeContainerd    = "containerd"
+	RuntimeCrio          = "crio"
+	RuntimeLxc           = "lxc"
+	RuntimeSystemdNspawn = "systemd-nspawn"
+	RuntimeWsl           = "wsl"
+)
+
+// RuntimeInfo 描述守护进程所在的运行环境，用于在 JVM 跑在 k8s 内时
+// 正确上报 pod/容器信息，替代过去只能判断 true/false 的 IsContainer
+type RuntimeInfo struct {
+	Kind          string `json:"kind"`          // host/docker/containerd/crio/lxc/systemd-nspawn/wsl
+	PodName       string `json:"podName"`       // k8s downward api POD_NAME
+	PodNamespace  string `json:"podNamespace"`  // k8s downward api POD_NAMESPACE
+	PodUID        string `json:"podUID"`        // k8s downward api POD_UID
+	ContainerID   string `json:"containerID"`   // 从 cgroup 路径解析出的容器 id
+	CgroupVersion int    `json:"cgroupVersion"` // 1 或 2
+	NodeName      string `json:"nodeName"`      // k8s downward api NODE_NAME
+}
+
+var (
+	kubepodsRe     = regexp.MustCompile(`kubepods.*?/(?:pod[^/]+/)?([0-9a-f]{64})`)
+	dockerScopeRe  = regexp.MustCompile(`/docker/([0-9a-f]{64})`)
+	crioScopeRe    = regexp.MustCompile(`crio-([0-9a-f]{64})`)
+	scopeContainer = regexp.MustCompile(`([0-9a-f]{64})\.scope`)
+
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// DetectRuntime 综合 cgroup、mountinfo、downward api 环境变量等信息
+// 判定守护进程所处的容器运行时
+func DetectRuntime() *RuntimeInfo {
+	info := &RuntimeInfo{
+		Kind:          RuntimeHost,
+		CgroupVersion: detectCgroupVersion(),
+	}
+
+	if isWsl() {
+		info.Kind = RuntimeWsl
+	} else if kind, containerID := detectFromCgroup(); kind != RuntimeHost {
+		info.Kind = kind
+		info.ContainerID = containerID
+	}
+
+	info.PodName = os.Getenv("POD_NAME")
+	info.PodNamespace = os.Getenv("POD_NAMESPACE")
+	info.PodUID = os.Getenv("POD_UID")
+	info.NodeName = os.Getenv("NODE_NAME")
+
+	// 没有 downward api 环境变量时，service account token 的存在也能说明在 k8s 内
+	if info.PodName == "" && info.Kind == RuntimeHost {
+		if existed, _ := utils.PathExists(serviceAccountTokenPath); existed {
+			info.Kind = RuntimeContainerd
+		}
+	}
+
+	return info
+}
+
+// detectFromCgroup 解析 /proc/1/cgroup 与 /proc/self/mountinfo，
+// 通过路径特征区分 docker/containerd/crio/lxc/systemd-nspawn
+func detectFromCgroup() (kind string, containerID string) {
+	b, err := os.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return RuntimeHost, ""
+	}
+	content := string(b)
+
+	if m := dockerScopeRe.FindStringSubmatch(content); m != nil {
+		return RuntimeDocker, m[1]
+	}
+	if m := crioScopeRe.FindStringSubmatch(content); m != nil {
+		return RuntimeCrio, m[1]
+	}
+	if m := kubepodsRe.FindStringSubmatch(content); m != nil {
+		return RuntimeContainerd, m[1]
+	}
+	if strings.Contains(content, "/lxc/") {
+		return RuntimeLxc, ""
+	}
+	if m := scopeContainer.FindStringSubmatch(content); m != nil {
+		return RuntimeSystemdNspawn, m[1]
+	}
+
+	// mountinfo 有时比 cgroup 路径更可靠（cgroup v2 下 1 号进程路径常被压缩为 "/"）
+	if mb, err := os.ReadFile("/proc/self/mountinfo"); err == nil {
+		mi := string(mb)
+		if m := dockerScopeRe.FindStringSubmatch(mi); m != nil {
+			return RuntimeDocker, m[1]
+		}
+		if m := kubepodsRe.FindStringSubmatch(mi); m != nil {
+			return RuntimeContainerd, m[1]
+		}
+	}
+
+	return RuntimeHost, ""
+}
+
+// detectCgroupVersion 返回 1 或 2；v2 系统存在 /sys/fs/cgroup/cgroup.controllers
+func detectCgroupVersion() int {
+	if existed, _ := utils.PathExists("/sys/fs/cgroup/cgroup.controllers"); existed {
+		return 2
+	}
+	return 1
+}
+
+// isWsl 判断内核是否为 WSL，/proc/version 中会包含 "microsoft"
+func isWsl() bool {
+	b, err := os.ReadFile("/proc/version")
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(b)), "microsoft")
+}
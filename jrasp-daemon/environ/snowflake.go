@@ -0,0 +1,122 @@
+package environ
+
+import (
+	"hash/fnv"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// snowflake 位分配：1 位符号位(恒为0) + 41 位毫秒时间戳 + 10 位节点位 + 12 位序列号
+const (
+	snowflakeNodeBits = 10
+	snowflakeSeqBits  = 12
+
+	snowflakeMaxNode = -1 ^ (-1 << snowflakeNodeBits)
+	snowflakeMaxSeq  = -1 ^ (-1 << snowflakeSeqBits)
+
+	snowflakeNodeShift = snowflakeSeqBits
+	snowflakeTimeShift = snowflakeSeqBits + snowflakeNodeBits
+)
+
+// BuildEpoch 是 snowflake 时间戳的起始纪元（unix 毫秒），可在编译期通过 -ldflags 覆盖
+var BuildEpoch = "1700000000000"
+
+// Generator 是一个 snowflake 风格的 id 生成器，单个 Generator 非跨进程安全，
+// 同一进程内通过 sync.Mutex 保证并发调用下的单调递增
+type Generator struct {
+	mu     sync.Mutex
+	epoch  int64
+	nodeID int64
+	lastMs int64
+	seq    int64
+}
+
+// NewGenerator 创建一个绑定了 nodeID 的生成器，nodeID 取值范围 [0, 1023]
+func NewGenerator(nodeID int64) *Generator {
+	epoch, err := strconv.ParseInt(BuildEpoch, 10, 64)
+	if err != nil {
+		epoch = 1700000000000
+	}
+	return &Generator{
+		epoch:  epoch,
+		nodeID: nodeID & snowflakeMaxNode,
+	}
+}
+
+// NextID 生成下一个单调递增、可排序的 id；同一毫秒内序列号耗尽时自旋等待下一毫秒
+func (g *Generator) NextID() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := nowMs()
+	if now < g.lastMs {
+		// 时钟回拨时沿用上一次的毫秒数，保证 id 不回退
+		now = g.lastMs
+	}
+	if now == g.lastMs {
+		g.seq = (g.seq + 1) & snowflakeMaxSeq
+		if g.seq == 0 {
+			for now <= g.lastMs {
+				now = nowMs()
+			}
+		}
+	} else {
+		g.seq = 0
+	}
+	g.lastMs = now
+
+	return (now-g.epoch)<<snowflakeTimeShift | g.nodeID<<snowflakeNodeShift | g.seq
+}
+
+func nowMs() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+var (
+	defaultGeneratorOnce sync.Once
+	defaultGenerator     *Generator
+)
+
+// NextID 基于包级默认生成器产生下一个 id，供日志行、事件上报等子系统
+// 标记单调递增且可排序的 id；首次调用前应先通过 initDefaultGenerator 设置节点 id
+func NextID() int64 {
+	defaultGeneratorOnce.Do(func() {
+		if defaultGenerator == nil {
+			defaultGenerator = NewGenerator(0)
+		}
+	})
+	return defaultGenerator.NextID()
+}
+
+// initDefaultGenerator 以 mac||installDir 的哈希作为节点 id 初始化包级默认生成器，
+// 使同一台宿主机上共存的多个 daemon 实例不会产生冲突的节点 id
+func initDefaultGenerator(installDir string) {
+	nodeID := nodeIDFromMacAndDir(installDir)
+	defaultGeneratorOnce.Do(func() {
+		defaultGenerator = NewGenerator(nodeID)
+	})
+}
+
+// nodeIDFromMacAndDir 对 mac 地址与安装目录做哈希，取低 10 位作为节点 id
+func nodeIDFromMacAndDir(installDir string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(firstMacAddr()))
+	h.Write([]byte(installDir))
+	return int64(h.Sum32()) & snowflakeMaxNode
+}
+
+// firstMacAddr 返回第一个非空的网卡 mac 地址，找不到时返回空字符串
+func firstMacAddr() string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+	for _, iface := range ifaces {
+		if len(iface.HardwareAddr) > 0 {
+			return iface.HardwareAddr.String()
+		}
+	}
+	return ""
+}